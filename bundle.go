@@ -0,0 +1,200 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/wavesplatform/gowaves/pkg/client"
+	"github.com/wavesplatform/gowaves/pkg/crypto"
+	"github.com/wavesplatform/gowaves/pkg/proto"
+)
+
+// maxProofs is the maximum number of proofs a Waves transaction can carry,
+// which bounds the number of signatures a multisig account can require.
+const maxProofs = 8
+
+// bundleEntry is a single lease cancel transaction carried inside a bundle,
+// alongside the lease ID it cancels and the fee it was prepared with.
+type bundleEntry struct {
+	LeaseID string          `json:"leaseId"`
+	Fee     uint64          `json:"fee"`
+	Tx      json.RawMessage `json:"tx"`
+}
+
+// bundle is the detached transaction bundle exchanged between the prepare,
+// sign and broadcast phases of the offline-signing workflow.
+type bundle struct {
+	Scheme       proto.Scheme  `json:"scheme"`
+	Height       uint64        `json:"height"`
+	Transactions []bundleEntry `json:"transactions"`
+}
+
+// runPrepare builds a bundle of unsigned lease cancel transactions for every
+// active leasing on addr that filter selects and writes it to bundlePath (or
+// stdout).
+func runPrepare(ctx context.Context, cl *client.Client, scheme proto.Scheme, pk crypto.PublicKey, addr proto.Address, filter leaseFilter, bundlePath string) error {
+	active, total, err := getActiveLeasings(ctx, cl, addr)
+	if err != nil {
+		return err
+	}
+	logf("info", "Found %d active leasings on account '%s' with the total amount of %s", len(active), addr.String(), format(total))
+	selected := selectLeasings(active, filter)
+	printSelectionSummary(active, selected)
+	height, _, err := cl.Blocks.Height(ctx)
+	if err != nil {
+		return err
+	}
+	entries := make([]bundleEntry, len(selected))
+	for i, lease := range selected {
+		draft := proto.NewUnsignedLeaseCancelWithProofs(2, scheme, pk, lease.ID, standardFee, timestamp())
+		fee, err := calculateFee(ctx, cl, draft)
+		if err != nil {
+			return err
+		}
+		tx := proto.NewUnsignedLeaseCancelWithProofs(2, scheme, pk, lease.ID, fee, timestamp())
+		b, err := json.Marshal(tx)
+		if err != nil {
+			return err
+		}
+		entries[i] = bundleEntry{LeaseID: lease.ID.String(), Fee: fee, Tx: b}
+	}
+	bdl := bundle{Scheme: scheme, Height: height.Height, Transactions: entries}
+	out, err := json.MarshalIndent(bdl, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeBundle(bundlePath, out)
+}
+
+// runSign reads a bundle, appends a proof for each of sks to every
+// transaction it contains, and writes the updated bundle back out. Up to
+// maxProofs secret keys can be applied, as the Waves protocol allows for
+// multisig accounts.
+func runSign(bundlePath string, sks []crypto.SecretKey) error {
+	bdl, err := readBundle(bundlePath)
+	if err != nil {
+		return err
+	}
+	for i, entry := range bdl.Transactions {
+		tx := new(proto.LeaseCancelWithProofs)
+		if err := json.Unmarshal(entry.Tx, tx); err != nil {
+			return fmt.Errorf("failed to parse transaction for lease '%s': %w", entry.LeaseID, err)
+		}
+		for _, sk := range sks {
+			if err := appendProof(bdl.Scheme, tx, sk); err != nil {
+				return fmt.Errorf("failed to sign transaction for lease '%s': %w", entry.LeaseID, err)
+			}
+		}
+		b, err := json.Marshal(tx)
+		if err != nil {
+			return err
+		}
+		bdl.Transactions[i].Tx = b
+	}
+	out, err := json.MarshalIndent(bdl, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeBundle(bundlePath, out)
+}
+
+// runBroadcast reads a fully-signed bundle and broadcasts its transactions,
+// tracking their confirmation the same way the single-phase flow does. If j
+// is not nil, entries already marked confirmed in the journal are skipped,
+// so resuming an interrupted run doesn't re-broadcast transactions the node
+// has already accepted.
+func runBroadcast(ctx context.Context, cl *client.Client, bundlePath string, parallelism int, j *journal) error {
+	bdl, err := readBundle(bundlePath)
+	if err != nil {
+		return err
+	}
+	var cancels []*proto.LeaseCancelWithProofs
+	skipped := 0
+	for _, entry := range bdl.Transactions {
+		if j != nil {
+			if e, ok := j.get(entry.LeaseID); ok && e.Status == journalStatusConfirmed {
+				skipped++
+				continue
+			}
+		}
+		tx := new(proto.LeaseCancelWithProofs)
+		if err := json.Unmarshal(entry.Tx, tx); err != nil {
+			return fmt.Errorf("failed to parse transaction for lease '%s': %w", entry.LeaseID, err)
+		}
+		cancels = append(cancels, tx)
+	}
+	if skipped > 0 {
+		logf("info", "Journal: %d lease cancellations already confirmed, skipping", skipped)
+	}
+	if err := broadcastBatch(ctx, cl, cancels, parallelism, j); err != nil {
+		return err
+	}
+	if err := trackBatch(ctx, cl, cancels, parallelism, j); err != nil {
+		return err
+	}
+	logf("info", "%d cancel transactions confirmed", len(cancels))
+	return nil
+}
+
+// appendProof signs tx's body with sk and stores the resulting signature in
+// the next free proof slot, without disturbing proofs already present. This
+// lets several independent signers contribute to the same multisig
+// transaction across separate -mode sign invocations.
+func appendProof(scheme proto.Scheme, tx *proto.LeaseCancelWithProofs, sk crypto.SecretKey) error {
+	if tx.Proofs == nil {
+		tx.Proofs = proto.NewProofs()
+	}
+	if len(tx.Proofs.Proofs) >= maxProofs {
+		return fmt.Errorf("transaction already carries the maximum of %d proofs", maxProofs)
+	}
+	body, err := proto.MarshalTxBody(scheme, tx)
+	if err != nil {
+		return err
+	}
+	if tx.ID == nil {
+		id, err := crypto.FastHash(body)
+		if err != nil {
+			return err
+		}
+		tx.ID = &id
+	}
+	return tx.Proofs.Sign(len(tx.Proofs.Proofs), sk, body)
+}
+
+// readBundle reads and parses a bundle from path, or from stdin if path is
+// empty or "-".
+func readBundle(path string) (*bundle, error) {
+	var r io.Reader
+	if path == "" || path == "-" {
+		r = os.Stdin
+	} else {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer func() { _ = f.Close() }()
+		r = f
+	}
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	bdl := new(bundle)
+	if err := json.Unmarshal(b, bdl); err != nil {
+		return nil, err
+	}
+	return bdl, nil
+}
+
+// writeBundle writes data to path, or to stdout if path is empty or "-".
+func writeBundle(path string, data []byte) error {
+	if path == "" || path == "-" {
+		_, err := os.Stdout.Write(append(bytes.TrimRight(data, "\n"), '\n'))
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
@@ -1,16 +1,17 @@
 package main
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
 	"flag"
 	"fmt"
-	"log"
 	"net/http"
 	"net/url"
 	"os"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/oguzbilgic/fpd"
@@ -32,8 +33,8 @@ var (
 	errFailure           = errors.New("operation failure")
 )
 
-type AddressesExtraFee struct {
-	ExtraFee uint64 `json:"extraFee"`
+type feeCalculation struct {
+	FeeAmount uint64 `json:"feeAmount"`
 }
 
 func main() {
@@ -61,6 +62,22 @@ func run() error {
 		dryRun      bool
 		showHelp    bool
 		showVersion bool
+		batchSize   int
+		parallelism int
+		journalPath string
+		signerKind  string
+		ledgerPath  string
+		mode        string
+		bundlePath  string
+		signKeys    string
+		recipient   string
+		minAmount   uint64
+		maxAmount   uint64
+		olderThan   string
+		leaseIDs    string
+		excludeIDs  string
+		metricsAddr string
+		logFmt      string
 	)
 	flag.StringVar(&nodeURL, "node-api", "http://localhost:6869", "Node's REST API URL")
 	flag.StringVar(&accountSK, "account-sk", "", "Base58 encoded private key of the account")
@@ -68,6 +85,22 @@ func run() error {
 	flag.BoolVar(&dryRun, "dry-run", false, "Test execution without creating real transactions on blockchain")
 	flag.BoolVar(&showHelp, "help", false, "Show usage information and exit")
 	flag.BoolVar(&showVersion, "version", false, "Print version information and quit")
+	flag.IntVar(&batchSize, "batch-size", 10, "Number of lease cancel transactions signed and broadcast together before tracking their confirmation")
+	flag.IntVar(&parallelism, "parallel", 4, "Number of worker goroutines used to sign, broadcast and track a batch")
+	flag.StringVar(&journalPath, "journal", "", "Path to a JSON-lines journal of broadcast cancel transactions, used to resume an interrupted run")
+	flag.StringVar(&signerKind, "signer", "memory", "Signer used to sign transactions: 'memory' (account-sk) or 'ledger' (Ledger USB device)")
+	flag.StringVar(&ledgerPath, "ledger-path", "44'/5741564'/0'/0'/0'", "BIP-32 derivation path of the account on the Ledger device, used when -signer=ledger")
+	flag.StringVar(&mode, "mode", "run", "Operating mode: 'run' (default, sign and broadcast in one step), 'prepare', 'sign' or 'broadcast' for the offline-signing workflow")
+	flag.StringVar(&bundlePath, "bundle", "-", "Path to the transaction bundle used by -mode prepare|sign|broadcast ('-' means stdin/stdout)")
+	flag.StringVar(&signKeys, "sign-keys", "", "Comma-separated Base58 encoded secret keys used to append proofs in -mode sign")
+	flag.StringVar(&recipient, "recipient", "", "Only cancel leases going to this address")
+	flag.Uint64Var(&minAmount, "min-amount", 0, "Only cancel leases of at least this amount")
+	flag.Uint64Var(&maxAmount, "max-amount", 0, "Only cancel leases of at most this amount (0 means no upper bound)")
+	flag.StringVar(&olderThan, "older-than", "", "Only cancel leases created at least this long ago, e.g. '30d', '12h'")
+	flag.StringVar(&leaseIDs, "lease-ids", "", "Path to a file listing lease IDs to cancel, one Base58 ID per line (an allow-list)")
+	flag.StringVar(&excludeIDs, "exclude-ids", "", "Path to a file listing lease IDs to keep, one Base58 ID per line (a deny-list)")
+	flag.StringVar(&metricsAddr, "metrics-addr", "", "Address to serve Prometheus metrics on, e.g. ':9090' (disabled if empty)")
+	flag.StringVar(&logFmt, "log-format", "text", "Log output format: 'text' (default, \"[LEVEL] message\") or 'json' (structured records)")
 	flag.Parse()
 
 	if showHelp {
@@ -78,33 +111,90 @@ func run() error {
 		fmt.Printf("Waves Leasing Canceller %s\n", version)
 		return nil
 	}
+	if logFmt != "text" && logFmt != "json" {
+		logf("error", "Invalid log format '%s'", logFmt)
+		return errInvalidParameters
+	}
+	logFormat = logFmt
 	if nodeURL == "" || len(strings.Fields(nodeURL)) > 1 {
-		log.Printf("[ERROR] Invalid node's URL '%s'", nodeURL)
+		logf("error", "Invalid node's URL '%s'", nodeURL)
 		return errInvalidParameters
 	}
-	if accountSK == "" || len(strings.Fields(accountSK)) > 1 {
-		log.Printf("[ERROR] Invalid generating account private key '%s'", accountSK)
+	if mode != "run" && mode != "prepare" && mode != "sign" && mode != "broadcast" {
+		logf("error", "Invalid mode '%s'", mode)
+		return errInvalidParameters
+	}
+	if mode == "run" || mode == "prepare" {
+		if signerKind != "memory" && signerKind != "ledger" {
+			logf("error", "Invalid signer '%s'", signerKind)
+			return errInvalidParameters
+		}
+		if mode == "run" && signerKind == "memory" && (accountSK == "" || len(strings.Fields(accountSK)) > 1) {
+			logf("error", "Invalid generating account private key '%s'", accountSK)
+			return errInvalidParameters
+		}
+		if mode == "prepare" && signerKind != "ledger" && accountPK == "" && accountSK == "" {
+			logf("error", "-mode prepare requires -account-pk, -account-sk or -signer ledger")
+			return errInvalidParameters
+		}
+	}
+	if mode == "sign" && signKeys == "" {
+		logf("error", "-mode sign requires -sign-keys")
 		return errInvalidParameters
 	}
 	if accountPK == "" || len(strings.Fields(accountPK)) > 1 {
-		log.Print("[INFO] No different account public key is given")
+		logf("info", "No different account public key is given")
 	}
 	if dryRun {
-		log.Print("[INFO] DRY-RUN: No actual transactions will be created")
+		logf("info", "DRY-RUN: No actual transactions will be created")
+	}
+	if batchSize < 1 {
+		logf("error", "Invalid batch size '%d'", batchSize)
+		return errInvalidParameters
+	}
+	if parallelism < 1 {
+		logf("error", "Invalid parallelism '%d'", parallelism)
+		return errInvalidParameters
+	}
+
+	// -mode sign is a fully offline operation: it never touches the node.
+	if mode == "sign" {
+		sks := make([]crypto.SecretKey, 0)
+		for _, s := range strings.Split(signKeys, ",") {
+			sk, err := crypto.NewSecretKeyFromBase58(strings.TrimSpace(s))
+			if err != nil {
+				logf("error", "Failed to parse signing key: %v", err)
+				return errFailure
+			}
+			sks = append(sks, sk)
+		}
+		if err := runSign(bundlePath, sks); err != nil {
+			logf("error", "Failed to sign bundle: %v", err)
+			return errFailure
+		}
+		logf("info", "OK")
+		return nil
 	}
 
 	ctx := interruptListener()
 
+	var metricsSrv *http.Server
+	if metricsAddr != "" {
+		metricsSrv = startMetricsServer(metricsAddr)
+		logf("info", "Serving Prometheus metrics on '%s'", metricsAddr)
+		defer stopMetricsServer(context.Background(), metricsSrv)
+	}
+
 	// 1. Check connection to node's API
 	cl, err := nodeClient(ctx, nodeURL)
 	if err != nil {
 		if errors.Is(err, context.Canceled) {
 			return errUserTermination
 		}
-		log.Printf("[ERROR] Failed to connect to node at '%s': %v", nodeURL, err)
+		logf("error", "Failed to connect to node at '%s': %v", nodeURL, err)
 		return errFailure
 	}
-	log.Printf("[INFO] Successfully connected to '%s'", cl.GetOptions().BaseUrl)
+	logf("info", "Successfully connected to '%s'", cl.GetOptions().BaseUrl)
 
 	// 2. Acquire the network scheme from genesis block
 	scheme, err := getScheme(ctx, cl)
@@ -112,104 +202,410 @@ func run() error {
 		if errors.Is(err, context.Canceled) {
 			return errUserTermination
 		}
-		log.Printf("[ERROR] Failed to aquire blockchain scheme: %v", err)
+		logf("error", "Failed to aquire blockchain scheme: %v", err)
 		return errFailure
 	}
-	log.Printf("[INFO] Blockchain scheme: %s", string(scheme))
+	logf("info", "Blockchain scheme: %s", string(scheme))
+
+	if mode == "broadcast" {
+		var j *journal
+		if journalPath != "" {
+			j, err = openJournal(journalPath)
+			if err != nil {
+				logf("error", "Failed to open journal '%s': %v", journalPath, err)
+				return errFailure
+			}
+			defer func() { _ = j.close() }()
+		}
+		if err := runBroadcast(ctx, cl, bundlePath, parallelism, j); err != nil {
+			if errors.Is(err, context.Canceled) {
+				return errUserTermination
+			}
+			logf("error", "Failed to broadcast bundle: %v", err)
+			return errFailure
+		}
+		logf("info", "OK")
+		return nil
+	}
 
-	// 3. Generate public keys and addresses from given private key
-	sk, pk, addr, err := parseSK(scheme, accountSK)
+	// 3. Set up the signer and derive the account's public key and address.
+	// -mode prepare can rely on -account-pk alone, as no signing happens in
+	// this mode, which keeps an air-gapped operator's secret key off this host.
+	var signer Signer
+	var pk crypto.PublicKey
+	if mode == "prepare" && accountPK != "" {
+		pk, err = crypto.NewPublicKeyFromBase58(accountPK)
+		if err != nil {
+			logf("error", "Failed to parse account's public key: %v", err)
+			return errFailure
+		}
+	} else {
+		signer, pk, err = newSigner(signerKind, accountSK, ledgerPath)
+		if err != nil {
+			logf("error", "Failed to initialize '%s' signer: %v", signerKind, err)
+			return errFailure
+		}
+	}
+	addr, err := proto.NewAddressFromPublicKey(scheme, pk)
 	if err != nil {
-		log.Printf("[ERROR] Failed to parse account's private key: %v", err)
+		logf("error", "Failed to parse account's address: %v", err)
 		return errFailure
 	}
-	if accountPK != "" {
+	if mode != "prepare" && accountPK != "" {
 		pk, err = crypto.NewPublicKeyFromBase58(accountPK)
 		if err != nil {
-			log.Printf("[ERROR] Failed to parse additional public key: %v", err)
+			logf("error", "Failed to parse additional public key: %v", err)
 			return errFailure
 		}
 		addr, err = proto.NewAddressFromPublicKey(scheme, pk)
 		if err != nil {
-			log.Printf("[ERROR] Failed to parse account's address: %v", err)
+			logf("error", "Failed to parse account's address: %v", err)
 			return errFailure
 		}
 	}
-	log.Printf("[INFO] Account's public key: %s", pk.String())
-	log.Printf("[INFO] Account's address: %s", addr.String())
+	logf("info", "Account's public key: %s", pk.String())
+	logfFields("info", logFields{Addr: addr.String()}, "Account's address: %s", addr.String())
 
-	// 4. Get active leasing transactions
-	leasings, total, err := getActiveLeasings(ctx, cl, addr)
-	if err != nil {
-		if errors.Is(err, context.Canceled) {
-			return errUserTermination
+	if mode == "prepare" {
+		filter, err := buildLeaseFilter(recipient, minAmount, maxAmount, olderThan, leaseIDs, excludeIDs)
+		if err != nil {
+			logf("error", "%v", err)
+			return errInvalidParameters
 		}
-		log.Printf("[ERROR] Failed to get active leasings: %v", err)
-		return errFailure
+		if err := runPrepare(ctx, cl, scheme, pk, addr, filter, bundlePath); err != nil {
+			if errors.Is(err, context.Canceled) {
+				return errUserTermination
+			}
+			logf("error", "Failed to prepare bundle: %v", err)
+			return errFailure
+		}
+		logf("info", "OK")
+		return nil
 	}
-	log.Printf("[INFO] Found %d active leasings on account '%s' with the total amount of %s", len(leasings), addr.String(), format(total))
 
-	// 4. Create cancel leasing transactions
-	leaseExtraFee, err := getExtraFee(ctx, cl, addr)
+	// 4. Get active leasing transactions and select which of them to cancel
+	active, total, err := getActiveLeasings(ctx, cl, addr)
 	if err != nil {
 		if errors.Is(err, context.Canceled) {
 			return errUserTermination
 		}
-		log.Printf("[ERROR] Failed to check extra fee on account '%s': %v", addr.String(), err)
+		logf("error", "Failed to get active leasings: %v", err)
 		return errFailure
 	}
-	if leaseExtraFee != 0 {
-		log.Printf("[INFO] Extra fee on cancel leasing: %s", format(leaseExtraFee))
-	} else {
-		log.Print("[INFO] No extra fee on cancel leasing")
+	logf("info", "Found %d active leasings on account '%s' with the total amount of %s", len(active), addr.String(), format(total))
+
+	filter, err := buildLeaseFilter(recipient, minAmount, maxAmount, olderThan, leaseIDs, excludeIDs)
+	if err != nil {
+		logf("error", "%v", err)
+		return errInvalidParameters
+	}
+	selected := selectLeasings(active, filter)
+	printSelectionSummary(active, selected)
+	leasings := make([]crypto.Digest, len(selected))
+	for i, l := range selected {
+		leasings[i] = l.ID
 	}
-	fee := standardFee + leaseExtraFee
-	for i, lease := range leasings {
-		cancel := proto.NewUnsignedLeaseCancelWithProofs(2, scheme, pk, lease, fee, timestamp())
-		err = cancel.Sign(scheme, sk)
+
+	// 5. Open the journal, if requested, and split leasings into already
+	// confirmed (skip), previously signed or broadcast (resume), and new
+	// (sign from scratch) groups.
+	var j *journal
+	if journalPath != "" {
+		j, err = openJournal(journalPath)
 		if err != nil {
-			log.Printf("[ERROR] Failed to sign lease cancel transaction: %v", err)
+			logf("error", "Failed to open journal '%s': %v", journalPath, err)
 			return errFailure
 		}
+		defer func() {
+			if j != nil {
+				_ = j.close()
+			}
+		}()
 		if dryRun {
-			b, err := json.Marshal(cancel)
-			if err != nil {
-				log.Printf("[ERROR] Failed to make transaction json: %v", err)
-				return errFailure
+			logf("info", "Journal is ignored in dry-run mode")
+			_ = j.close()
+			j = nil
+		}
+	}
+
+	pending := leasings
+	var resumed []*proto.LeaseCancelWithProofs
+	if j != nil {
+		pending = nil
+		skipped := 0
+		for _, lease := range leasings {
+			entry, ok := j.get(lease.String())
+			if !ok {
+				pending = append(pending, lease)
+				continue
 			}
-			log.Printf("[INFO] Cancel transaction #%d:\n%s", i+1, string(b))
-		} else {
-			log.Printf("[INFO] Cancel transaction #%d ID: %s", i+1, cancel.ID.String())
-			err = broadcast(ctx, cl, cancel)
-			if err != nil {
-				if errors.Is(err, context.Canceled) {
-					return errUserTermination
-				}
-				log.Printf("[ERROR] Failed to broadcast lease transaction: %v", err)
+			if entry.Status == journalStatusConfirmed {
+				skipped++
+				continue
+			}
+			cancel := new(proto.LeaseCancelWithProofs)
+			if err := json.Unmarshal(entry.Tx, cancel); err != nil {
+				logfFields("error", logFields{LeaseID: lease.String()}, "Failed to restore journaled transaction for lease '%s': %v", lease.String(), err)
 				return errFailure
 			}
-			err = track(ctx, cl, *cancel.ID)
-			if err != nil {
-				if errors.Is(err, context.Canceled) {
-					return errUserTermination
+			resumed = append(resumed, cancel)
+		}
+		if skipped > 0 {
+			logf("info", "Journal: %d lease cancellations already confirmed, skipping", skipped)
+		}
+		if len(resumed) > 0 {
+			logf("info", "Journal: resuming %d previously signed lease cancellations", len(resumed))
+		}
+	}
+
+	// 6. Create, sign and broadcast cancel leasing transactions in batches
+	done := len(leasings) - len(pending) - len(resumed)
+	if len(resumed) > 0 {
+		if err := broadcastBatch(ctx, cl, resumed, parallelism, j); err != nil {
+			if errors.Is(err, context.Canceled) {
+				return errUserTermination
+			}
+			logf("error", "Failed to re-broadcast journaled lease cancel transactions: %v", err)
+			return errFailure
+		}
+		if err := trackBatch(ctx, cl, resumed, parallelism, j); err != nil {
+			if errors.Is(err, context.Canceled) {
+				return errUserTermination
+			}
+			logf("error", "Failed to confirm journaled lease cancel transactions: %v", err)
+			return errFailure
+		}
+		done += len(resumed)
+		printProgress(done, len(leasings))
+	}
+	for start := 0; start < len(pending); start += batchSize {
+		end := start + batchSize
+		if end > len(pending) {
+			end = len(pending)
+		}
+		batch := pending[start:end]
+		cancels, err := signBatch(ctx, cl, scheme, pk, signer, batch, parallelism, j)
+		if err != nil {
+			if errors.Is(err, context.Canceled) {
+				return errUserTermination
+			}
+			logf("error", "Failed to sign lease cancel transactions: %v", err)
+			return errFailure
+		}
+		if dryRun {
+			for _, cancel := range cancels {
+				b, err := json.Marshal(cancel)
+				if err != nil {
+					logf("error", "Failed to make transaction json: %v", err)
+					return errFailure
 				}
-				log.Printf("[ERROR] Failed to track lease cancel transaction: %v", err)
-				return errFailure
+				logf("info", "Cancel transaction:\n%s", string(b))
+			}
+			done += len(cancels)
+			printProgress(done, len(leasings))
+			continue
+		}
+		if err := broadcastBatch(ctx, cl, cancels, parallelism, j); err != nil {
+			if errors.Is(err, context.Canceled) {
+				return errUserTermination
 			}
+			logf("error", "Failed to broadcast lease cancel transactions: %v", err)
+			return errFailure
 		}
+		if err := trackBatch(ctx, cl, cancels, parallelism, j); err != nil {
+			if errors.Is(err, context.Canceled) {
+				return errUserTermination
+			}
+			logf("error", "Failed to track lease cancel transactions: %v", err)
+			return errFailure
+		}
+		done += len(cancels)
+		printProgress(done, len(leasings))
 	}
-	log.Printf("[INFO] %d cancel transactions created", len(leasings))
-	log.Print("[INFO] OK")
+	logf("info", "%d cancel transactions created", len(leasings))
+	logf("info", "OK")
 	return nil
 }
 
+// signBatch signs cancel transactions for the given leases using up to parallelism
+// concurrent workers. The fee of each transaction is discovered by asking the node
+// to calculate it, so accounts on nodes with custom fee tables pay the right amount.
+// If j is not nil, each signed transaction is journaled before this function returns.
+func signBatch(ctx context.Context, cl *client.Client, scheme proto.Scheme, pk crypto.PublicKey, signer Signer, leases []crypto.Digest, parallelism int, j *journal) ([]*proto.LeaseCancelWithProofs, error) {
+	cancels := make([]*proto.LeaseCancelWithProofs, len(leases))
+	return cancels, runWorkers(ctx, len(leases), parallelism, func(i int) error {
+		draft := proto.NewUnsignedLeaseCancelWithProofs(2, scheme, pk, leases[i], standardFee, timestamp())
+		fee, err := calculateFee(ctx, cl, draft)
+		if err != nil {
+			return err
+		}
+		cancel := proto.NewUnsignedLeaseCancelWithProofs(2, scheme, pk, leases[i], fee, timestamp())
+		if err := signer.SignTx(scheme, cancel); err != nil {
+			return err
+		}
+		cancels[i] = cancel
+		cancelsSignedTotal.Inc()
+		extraFeeWaves.Set(float64(int64(fee)-int64(standardFee)) / 1e8)
+		if j == nil {
+			return nil
+		}
+		txJSON, err := json.Marshal(cancel)
+		if err != nil {
+			return err
+		}
+		return j.append(journalEntry{
+			LeaseID:   leases[i].String(),
+			TxID:      cancel.ID.String(),
+			Timestamp: timestamp(),
+			Status:    journalStatusSigned,
+			Tx:        txJSON,
+		})
+	})
+}
+
+// broadcastBatch broadcasts the given cancel transactions using up to parallelism
+// concurrent workers. If j is not nil, each broadcast transaction is journaled.
+func broadcastBatch(ctx context.Context, cl *client.Client, cancels []*proto.LeaseCancelWithProofs, parallelism int, j *journal) error {
+	return runWorkers(ctx, len(cancels), parallelism, func(i int) error {
+		logfFields("info", logFields{LeaseID: cancels[i].LeaseID.String(), TxID: cancels[i].ID.String()}, "Broadcasting cancel transaction '%s'", cancels[i].ID.String())
+		start := time.Now()
+		err := broadcast(ctx, cl, cancels[i])
+		broadcastLatencySeconds.Observe(time.Since(start).Seconds())
+		if err != nil {
+			broadcastFailuresTotal.Inc()
+			return err
+		}
+		cancelsBroadcastTotal.Inc()
+		if j == nil {
+			return nil
+		}
+		txJSON, err := json.Marshal(cancels[i])
+		if err != nil {
+			return err
+		}
+		return j.append(journalEntry{
+			LeaseID:   cancels[i].LeaseID.String(),
+			TxID:      cancels[i].ID.String(),
+			Timestamp: timestamp(),
+			Status:    journalStatusBroadcast,
+			Tx:        txJSON,
+		})
+	})
+}
+
+// trackBatch waits for confirmation of the given cancel transactions using up to
+// parallelism concurrent workers. If j is not nil, each confirmed transaction is
+// journaled together with the node's height at confirmation time.
+func trackBatch(ctx context.Context, cl *client.Client, cancels []*proto.LeaseCancelWithProofs, parallelism int, j *journal) error {
+	return runWorkers(ctx, len(cancels), parallelism, func(i int) error {
+		start := time.Now()
+		if err := track(ctx, cl, *cancels[i].ID); err != nil {
+			return err
+		}
+		cancelConfirmationSeconds.Observe(time.Since(start).Seconds())
+		cancelsConfirmedTotal.Inc()
+		if j == nil {
+			return nil
+		}
+		height, _, err := cl.Blocks.Height(ctx)
+		if err != nil {
+			return err
+		}
+		nodeHeight.Set(float64(height.Height))
+		return j.append(journalEntry{
+			LeaseID:   cancels[i].LeaseID.String(),
+			TxID:      cancels[i].ID.String(),
+			Timestamp: timestamp(),
+			Status:    journalStatusConfirmed,
+			Height:    height.Height,
+		})
+	})
+}
+
+// runWorkers runs fn(i) for every i in [0,n) using up to parallelism concurrent
+// goroutines, and returns the first error encountered, if any.
+func runWorkers(ctx context.Context, n, parallelism int, fn func(i int) error) error {
+	if parallelism > n {
+		parallelism = n
+	}
+	jobs := make(chan int)
+	errs := make(chan error, parallelism)
+	failed := make(chan struct{})
+	var failOnce sync.Once
+	var wg sync.WaitGroup
+	for w := 0; w < parallelism; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				if err := fn(i); err != nil {
+					errs <- err
+					failOnce.Do(func() { close(failed) })
+					return
+				}
+			}
+		}()
+	}
+sending:
+	for i := 0; i < n; i++ {
+		select {
+		case jobs <- i:
+		case <-failed:
+			break sending
+		case <-ctx.Done():
+			break sending
+		}
+	}
+	close(jobs)
+	wg.Wait()
+	select {
+	case err := <-errs:
+		return err
+	default:
+		return ctx.Err()
+	}
+}
+
+// printProgress reports aggregate progress of the ongoing batch run.
+func printProgress(done, total int) {
+	pct := 100
+	if total > 0 {
+		pct = done * 100 / total
+	}
+	logf("info", "Progress: %d/%d (%d%%)", done, total, pct)
+}
+
+// calculateFee asks the node to calculate the correct fee for tx, so that accounts
+// with custom fee tables (e.g. scripted accounts with an extra lease-cancel fee)
+// are charged correctly instead of relying on a hardcoded standard fee.
+func calculateFee(ctx context.Context, cl *client.Client, tx proto.Transaction) (uint64, error) {
+	b, err := json.Marshal(tx)
+	if err != nil {
+		return 0, err
+	}
+	req, err := http.NewRequest("POST", fmt.Sprintf("%s/transactions/calculateFee", cl.GetOptions().BaseUrl), bytes.NewReader(b))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	fc := new(feeCalculation)
+	r, err := cl.Do(ctx, req, fc)
+	if err != nil {
+		return 0, err
+	}
+	if r.StatusCode != http.StatusOK {
+		return 0, errors.New("failed to calculate fee")
+	}
+	return fc.FeeAmount, nil
+}
+
 func broadcast(ctx context.Context, cl *client.Client, tx proto.Transaction) error {
 	_, err := cl.Transactions.Broadcast(ctx, tx)
 	return err
 }
 
 func track(ctx context.Context, cl *client.Client, id crypto.Digest) error {
-	log.Printf("[INFO] Waiting for transaction '%s' on blockchain...", id.String())
+	logfFields("info", logFields{TxID: id.String()}, "Waiting for transaction '%s' on blockchain...", id.String())
 	for {
 		_, rsp, err := cl.Transactions.Info(ctx, id)
 		if errors.Is(err, context.Canceled) {
@@ -231,36 +627,6 @@ func format(amount uint64) string {
 	return fmt.Sprintf("%s WAVES", da.FormattedString())
 }
 
-func getActiveLeasings(ctx context.Context, cl *client.Client, addr proto.Address) ([]crypto.Digest, uint64, error) {
-	txs, _, err := cl.Leasing.Active(ctx, addr)
-	if err != nil {
-		return nil, 0, err
-	}
-	var amount uint64 = 0
-	r := make([]crypto.Digest, len(txs))
-	for i := range txs {
-		amount += txs[i].Amount
-		r[i] = *txs[i].ID
-	}
-	return r, amount, nil
-}
-
-func getExtraFee(ctx context.Context, cl *client.Client, addr proto.Address) (uint64, error) {
-	req, err := http.NewRequest("GET", fmt.Sprintf("%s/addresses/scriptInfo/%s", cl.GetOptions().BaseUrl, addr.String()), nil)
-	if err != nil {
-		return 0, err
-	}
-	extraFee := new(AddressesExtraFee)
-	r, err := cl.Do(ctx, req, extraFee)
-	if err != nil {
-		return 0, err
-	}
-	if r.StatusCode != http.StatusOK {
-		return 0, errors.New("failed to get extra fee")
-	}
-	return extraFee.ExtraFee, nil
-}
-
 func nodeClient(ctx context.Context, s string) (*client.Client, error) {
 	var u *url.URL
 	var err error
@@ -282,10 +648,11 @@ func nodeClient(ctx context.Context, s string) (*client.Client, error) {
 	if err != nil {
 		return nil, err
 	}
-	_, _, err = cl.Blocks.Height(ctx)
+	height, _, err := cl.Blocks.Height(ctx)
 	if err != nil {
 		return nil, err
 	}
+	nodeHeight.Set(float64(height.Height))
 	return cl, nil
 }
 
@@ -301,16 +668,3 @@ func showUsage() {
 	_, _ = fmt.Fprintf(os.Stderr, "\nUsage of Waves Automatic Lessor %s\n", version)
 	flag.PrintDefaults()
 }
-
-func parseSK(scheme proto.Scheme, s string) (crypto.SecretKey, crypto.PublicKey, proto.Address, error) {
-	sk, err := crypto.NewSecretKeyFromBase58(s)
-	if err != nil {
-		return crypto.SecretKey{}, crypto.PublicKey{}, proto.Address{}, err
-	}
-	pk := crypto.GeneratePublicKey(sk)
-	address, err := proto.NewAddressFromPublicKey(scheme, pk)
-	if err != nil {
-		return crypto.SecretKey{}, crypto.PublicKey{}, proto.Address{}, err
-	}
-	return sk, pk, address, nil
-}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/wavesplatform/gowaves/pkg/client"
+	"github.com/wavesplatform/gowaves/pkg/crypto"
+	"github.com/wavesplatform/gowaves/pkg/proto"
+)
+
+// leaseInfo describes a single active leasing transaction, with enough detail
+// to decide whether it should be cancelled.
+type leaseInfo struct {
+	ID        crypto.Digest
+	Recipient string
+	Amount    uint64
+	Timestamp uint64
+}
+
+// leaseFilter selects a subset of active leasings to cancel. A zero value
+// matches every leasing.
+type leaseFilter struct {
+	Recipient string
+	MinAmount uint64
+	MaxAmount uint64
+	OlderThan time.Duration
+	HasAllow  bool
+	Allow     map[string]struct{}
+	Deny      map[string]struct{}
+}
+
+// matches reports whether l should be cancelled under f, given the current
+// time used to evaluate OlderThan.
+func (f leaseFilter) matches(l leaseInfo, now time.Time) bool {
+	if f.HasAllow {
+		if _, ok := f.Allow[l.ID.String()]; !ok {
+			return false
+		}
+	}
+	if _, ok := f.Deny[l.ID.String()]; ok {
+		return false
+	}
+	if f.Recipient != "" && l.Recipient != f.Recipient {
+		return false
+	}
+	if f.MinAmount > 0 && l.Amount < f.MinAmount {
+		return false
+	}
+	if f.MaxAmount > 0 && l.Amount > f.MaxAmount {
+		return false
+	}
+	if f.OlderThan > 0 && now.Sub(time.UnixMilli(int64(l.Timestamp))) < f.OlderThan {
+		return false
+	}
+	return true
+}
+
+// selectLeasings returns the subset of leasings matched by f.
+func selectLeasings(leasings []leaseInfo, f leaseFilter) []leaseInfo {
+	now := time.Now()
+	selected := make([]leaseInfo, 0, len(leasings))
+	for _, l := range leasings {
+		if f.matches(l, now) {
+			selected = append(selected, l)
+		}
+	}
+	return selected
+}
+
+// buildLeaseFilter assembles a leaseFilter from the raw flag values, loading
+// the allow/deny lists from disk if given.
+func buildLeaseFilter(recipient string, minAmount, maxAmount uint64, olderThan, leaseIDsPath, excludeIDsPath string) (leaseFilter, error) {
+	f := leaseFilter{Recipient: recipient, MinAmount: minAmount, MaxAmount: maxAmount}
+	if olderThan != "" {
+		d, err := parseAge(olderThan)
+		if err != nil {
+			return leaseFilter{}, fmt.Errorf("invalid -older-than value '%s': %w", olderThan, err)
+		}
+		f.OlderThan = d
+	}
+	if leaseIDsPath != "" {
+		ids, err := readIDList(leaseIDsPath)
+		if err != nil {
+			return leaseFilter{}, fmt.Errorf("failed to read -lease-ids file '%s': %w", leaseIDsPath, err)
+		}
+		f.HasAllow = true
+		f.Allow = ids
+	}
+	if excludeIDsPath != "" {
+		ids, err := readIDList(excludeIDsPath)
+		if err != nil {
+			return leaseFilter{}, fmt.Errorf("failed to read -exclude-ids file '%s': %w", excludeIDsPath, err)
+		}
+		f.Deny = ids
+	}
+	return f, nil
+}
+
+// parseAge parses a duration such as "30d", "12h" or "45m". Unlike
+// time.ParseDuration it additionally understands a "d" (day) unit.
+func parseAge(s string) (time.Duration, error) {
+	if strings.HasSuffix(s, "d") {
+		days, err := strconv.ParseFloat(strings.TrimSuffix(s, "d"), 64)
+		if err != nil {
+			return 0, err
+		}
+		return time.Duration(days * float64(24*time.Hour)), nil
+	}
+	return time.ParseDuration(s)
+}
+
+// readIDList reads one Base58 lease ID per line from path into a set.
+func readIDList(path string) (map[string]struct{}, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+	ids := make(map[string]struct{})
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		ids[line] = struct{}{}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// getActiveLeasings fetches every active leasing transaction on addr, along
+// with the total leased amount across all of them (before any filtering).
+func getActiveLeasings(ctx context.Context, cl *client.Client, addr proto.Address) ([]leaseInfo, uint64, error) {
+	txs, _, err := cl.Leasing.Active(ctx, addr)
+	if err != nil {
+		return nil, 0, err
+	}
+	var amount uint64 = 0
+	r := make([]leaseInfo, len(txs))
+	for i := range txs {
+		amount += txs[i].Amount
+		r[i] = leaseInfo{
+			ID:        *txs[i].ID,
+			Recipient: txs[i].Recipient.String(),
+			Amount:    txs[i].Amount,
+			Timestamp: txs[i].Timestamp,
+		}
+	}
+	leasesDiscoveredTotal.Add(float64(len(r)))
+	return r, amount, nil
+}
+
+// printSelectionSummary prints a dry-run table of every active leasing and
+// whether it was selected for cancellation, before any transaction is built.
+func printSelectionSummary(leasings []leaseInfo, selected []leaseInfo) {
+	selectedIDs := make(map[string]struct{}, len(selected))
+	for _, l := range selected {
+		selectedIDs[l.ID.String()] = struct{}{}
+	}
+	logf("info", "Lease selection summary:")
+	for _, l := range leasings {
+		action := "skip"
+		if _, ok := selectedIDs[l.ID.String()]; ok {
+			action = "cancel"
+		}
+		logf("info", "  %-6s %s -> %s  %s", action, l.ID.String(), l.Recipient, format(l.Amount))
+	}
+	logf("info", "%d of %d active leasings selected for cancellation", len(selected), len(leasings))
+}
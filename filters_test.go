@@ -0,0 +1,180 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/wavesplatform/gowaves/pkg/crypto"
+)
+
+func testLease(t *testing.T, id string, recipient string, amount uint64, timestamp uint64) leaseInfo {
+	t.Helper()
+	d, err := crypto.NewDigestFromBase58(id)
+	if err != nil {
+		t.Fatalf("NewDigestFromBase58(%q): %v", id, err)
+	}
+	return leaseInfo{ID: d, Recipient: recipient, Amount: amount, Timestamp: timestamp}
+}
+
+func TestLeaseFilterMatches(t *testing.T) {
+	const (
+		id1 = "11111111111111111111111111111111"
+		id2 = "22222222222222222222222222222222"
+	)
+	now := time.Now()
+	dayAgo := uint64(now.Add(-24 * time.Hour).UnixMilli())
+	hourAgo := uint64(now.Add(-time.Hour).UnixMilli())
+
+	tests := []struct {
+		name string
+		f    leaseFilter
+		l    leaseInfo
+		want bool
+	}{
+		{
+			name: "zero value matches everything",
+			f:    leaseFilter{},
+			l:    testLease(t, id1, "addr1", 100, hourAgo),
+			want: true,
+		},
+		{
+			name: "explicit empty allow-list matches nothing",
+			f:    leaseFilter{HasAllow: true, Allow: map[string]struct{}{}},
+			l:    testLease(t, id1, "addr1", 100, hourAgo),
+			want: false,
+		},
+		{
+			name: "allow-list admits listed lease",
+			f:    leaseFilter{HasAllow: true, Allow: map[string]struct{}{id1: {}}},
+			l:    testLease(t, id1, "addr1", 100, hourAgo),
+			want: true,
+		},
+		{
+			name: "allow-list rejects unlisted lease",
+			f:    leaseFilter{HasAllow: true, Allow: map[string]struct{}{id2: {}}},
+			l:    testLease(t, id1, "addr1", 100, hourAgo),
+			want: false,
+		},
+		{
+			name: "deny-list overrides allow-list for the same lease",
+			f: leaseFilter{
+				HasAllow: true,
+				Allow:    map[string]struct{}{id1: {}},
+				Deny:     map[string]struct{}{id1: {}},
+			},
+			l:    testLease(t, id1, "addr1", 100, hourAgo),
+			want: false,
+		},
+		{
+			name: "recipient mismatch excludes",
+			f:    leaseFilter{Recipient: "addr2"},
+			l:    testLease(t, id1, "addr1", 100, hourAgo),
+			want: false,
+		},
+		{
+			name: "amount below MinAmount excludes",
+			f:    leaseFilter{MinAmount: 200},
+			l:    testLease(t, id1, "addr1", 100, hourAgo),
+			want: false,
+		},
+		{
+			name: "amount above MaxAmount excludes",
+			f:    leaseFilter{MaxAmount: 50},
+			l:    testLease(t, id1, "addr1", 100, hourAgo),
+			want: false,
+		},
+		{
+			name: "amount within bounds admits",
+			f:    leaseFilter{MinAmount: 50, MaxAmount: 150},
+			l:    testLease(t, id1, "addr1", 100, hourAgo),
+			want: true,
+		},
+		{
+			name: "younger than OlderThan excludes",
+			f:    leaseFilter{OlderThan: 24 * time.Hour},
+			l:    testLease(t, id1, "addr1", 100, hourAgo),
+			want: false,
+		},
+		{
+			name: "at least as old as OlderThan admits",
+			f:    leaseFilter{OlderThan: 24 * time.Hour},
+			l:    testLease(t, id1, "addr1", 100, dayAgo),
+			want: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.f.matches(tt.l, now); got != tt.want {
+				t.Errorf("matches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseAge(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    time.Duration
+		wantErr bool
+	}{
+		{in: "30d", want: 30 * 24 * time.Hour},
+		{in: "12h", want: 12 * time.Hour},
+		{in: "0.5d", want: 12 * time.Hour},
+		{in: "not-a-duration", wantErr: true},
+		{in: "bogusd", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := parseAge(tt.in)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("parseAge(%q) = %v, want error", tt.in, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseAge(%q): %v", tt.in, err)
+			}
+			if got != tt.want {
+				t.Errorf("parseAge(%q) = %v, want %v", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadIDList(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ids.txt")
+	content := "id-1\n# a comment\n\nid-2\n  \nid-3\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	ids, err := readIDList(path)
+	if err != nil {
+		t.Fatalf("readIDList: %v", err)
+	}
+	want := map[string]struct{}{"id-1": {}, "id-2": {}, "id-3": {}}
+	if len(ids) != len(want) {
+		t.Fatalf("readIDList = %v, want %v", ids, want)
+	}
+	for id := range want {
+		if _, ok := ids[id]; !ok {
+			t.Errorf("missing id %q", id)
+		}
+	}
+}
+
+func TestReadIDListEmptyFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "empty.txt")
+	if err := os.WriteFile(path, []byte("# only comments\n\n"), 0644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	ids, err := readIDList(path)
+	if err != nil {
+		t.Fatalf("readIDList: %v", err)
+	}
+	if len(ids) != 0 {
+		t.Errorf("readIDList = %v, want empty", ids)
+	}
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// journalStatus tracks the lifecycle of a single lease cancel transaction as
+// recorded in the journal file.
+type journalStatus string
+
+const (
+	journalStatusSigned    journalStatus = "signed"
+	journalStatusBroadcast journalStatus = "broadcast"
+	journalStatusConfirmed journalStatus = "confirmed"
+)
+
+// journalEntry is a single JSON-lines record. A lease ID may appear more than
+// once in the file, the latest record for a given lease ID wins.
+type journalEntry struct {
+	LeaseID   string          `json:"leaseId"`
+	TxID      string          `json:"txId"`
+	Timestamp uint64          `json:"timestamp"`
+	Status    journalStatus   `json:"status"`
+	Height    uint64          `json:"confirmationHeight,omitempty"`
+	Tx        json.RawMessage `json:"tx,omitempty"`
+}
+
+// journal is an append-only on-disk log of signed and broadcast cancel
+// transactions, used to resume an interrupted run without re-signing or
+// losing track of transactions that are already in flight.
+type journal struct {
+	mu      sync.Mutex
+	file    *os.File
+	byLease map[string]journalEntry
+}
+
+// openJournal loads an existing journal file at path, if any, and opens it
+// for appending further entries.
+func openJournal(path string) (*journal, error) {
+	byLease := make(map[string]journalEntry)
+	existing, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		sc := bufio.NewScanner(bytes.NewReader(existing))
+		for sc.Scan() {
+			line := sc.Bytes()
+			if len(line) == 0 {
+				continue
+			}
+			var e journalEntry
+			if err := json.Unmarshal(line, &e); err != nil {
+				return nil, fmt.Errorf("corrupt journal entry: %w", err)
+			}
+			byLease[e.LeaseID] = e
+		}
+		if err := sc.Err(); err != nil {
+			return nil, err
+		}
+	case os.IsNotExist(err):
+	default:
+		return nil, err
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return &journal{file: f, byLease: byLease}, nil
+}
+
+// get returns the latest known journal entry for the given lease ID.
+func (j *journal) get(leaseID string) (journalEntry, bool) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	e, ok := j.byLease[leaseID]
+	return e, ok
+}
+
+// append writes a new record for e to the journal file and updates the
+// in-memory view of the latest status for e.LeaseID.
+func (j *journal) append(e journalEntry) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	b, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	b = append(b, '\n')
+	if _, err := j.file.Write(b); err != nil {
+		return err
+	}
+	j.byLease[e.LeaseID] = e
+	return nil
+}
+
+func (j *journal) close() error {
+	return j.file.Close()
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestOpenJournalMissingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal.jsonl")
+	j, err := openJournal(path)
+	if err != nil {
+		t.Fatalf("openJournal: %v", err)
+	}
+	defer func() { _ = j.close() }()
+	if _, ok := j.get("lease-1"); ok {
+		t.Fatalf("get on empty journal returned an entry")
+	}
+}
+
+func TestOpenJournalLatestEntryWins(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal.jsonl")
+	lines := []journalEntry{
+		{LeaseID: "lease-1", TxID: "tx-1", Status: journalStatusSigned},
+		{LeaseID: "lease-1", TxID: "tx-1", Status: journalStatusBroadcast},
+		{LeaseID: "lease-2", TxID: "tx-2", Status: journalStatusSigned},
+	}
+	writeJournalLines(t, path, lines)
+
+	j, err := openJournal(path)
+	if err != nil {
+		t.Fatalf("openJournal: %v", err)
+	}
+	defer func() { _ = j.close() }()
+
+	e, ok := j.get("lease-1")
+	if !ok {
+		t.Fatalf("expected an entry for lease-1")
+	}
+	if e.Status != journalStatusBroadcast {
+		t.Errorf("status = %q, want %q", e.Status, journalStatusBroadcast)
+	}
+
+	e, ok = j.get("lease-2")
+	if !ok || e.Status != journalStatusSigned {
+		t.Errorf("lease-2 entry = %+v, ok = %v, want signed entry", e, ok)
+	}
+}
+
+func TestOpenJournalCorruptEntry(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal.jsonl")
+	if err := os.WriteFile(path, []byte("not json\n"), 0644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	if _, err := openJournal(path); err == nil {
+		t.Fatalf("expected an error for a corrupt journal entry")
+	}
+}
+
+func TestJournalAppendPersistsAndMergesOnReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal.jsonl")
+
+	j, err := openJournal(path)
+	if err != nil {
+		t.Fatalf("openJournal: %v", err)
+	}
+	if err := j.append(journalEntry{LeaseID: "lease-1", TxID: "tx-1", Status: journalStatusSigned}); err != nil {
+		t.Fatalf("append: %v", err)
+	}
+	if err := j.append(journalEntry{LeaseID: "lease-1", TxID: "tx-1", Status: journalStatusConfirmed, Height: 42}); err != nil {
+		t.Fatalf("append: %v", err)
+	}
+	if err := j.close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+
+	reopened, err := openJournal(path)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	defer func() { _ = reopened.close() }()
+	e, ok := reopened.get("lease-1")
+	if !ok {
+		t.Fatalf("expected an entry for lease-1 after reload")
+	}
+	if e.Status != journalStatusConfirmed || e.Height != 42 {
+		t.Errorf("reloaded entry = %+v, want status confirmed at height 42", e)
+	}
+}
+
+func writeJournalLines(t *testing.T, path string, entries []journalEntry) {
+	t.Helper()
+	var b []byte
+	for _, e := range entries {
+		line, err := json.Marshal(e)
+		if err != nil {
+			t.Fatalf("Marshal: %v", err)
+		}
+		b = append(b, line...)
+		b = append(b, '\n')
+	}
+	if err := os.WriteFile(path, b, 0644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+}
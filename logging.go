@@ -0,0 +1,63 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+// logFormat selects how logf renders its output: "text" (the default)
+// reproduces the tool's original "[LEVEL] message" lines, "json" emits
+// structured records instead. Set by the -log-format flag.
+var logFormat = "text"
+
+// logFields carries the optional structured context attached to a single log
+// record under -log-format json: the lease, transaction or account an
+// operation concerns.
+type logFields struct {
+	LeaseID string
+	TxID    string
+	Addr    string
+}
+
+// logRecord is the shape of a single -log-format json line.
+type logRecord struct {
+	Level   string `json:"level"`
+	Time    string `json:"ts"`
+	Message string `json:"msg"`
+	LeaseID string `json:"lease_id,omitempty"`
+	TxID    string `json:"tx_id,omitempty"`
+	Addr    string `json:"addr,omitempty"`
+}
+
+// logf logs a formatted message at the given level ("info" or "error").
+func logf(level, format string, args ...interface{}) {
+	logfFields(level, logFields{}, format, args...)
+}
+
+// logfFields is like logf, but additionally attaches fields to the record
+// when logging in JSON format, so operators can filter or aggregate by
+// lease, transaction or account without parsing the message text.
+func logfFields(level string, fields logFields, format string, args ...interface{}) {
+	msg := fmt.Sprintf(format, args...)
+	if logFormat != "json" {
+		log.Printf("[%s] %s", strings.ToUpper(level), msg)
+		return
+	}
+	r := logRecord{
+		Level:   level,
+		Time:    time.Now().UTC().Format(time.RFC3339Nano),
+		Message: msg,
+		LeaseID: fields.LeaseID,
+		TxID:    fields.TxID,
+		Addr:    fields.Addr,
+	}
+	b, err := json.Marshal(r)
+	if err != nil {
+		log.Printf("[ERROR] failed to marshal log record: %v", err)
+		return
+	}
+	fmt.Println(string(b))
+}
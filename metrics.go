@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+var (
+	leasesDiscoveredTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "leases_discovered_total",
+		Help: "Total number of active leasing transactions discovered on the account.",
+	})
+	cancelsSignedTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "cancels_signed_total",
+		Help: "Total number of lease cancel transactions signed.",
+	})
+	cancelsBroadcastTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "cancels_broadcast_total",
+		Help: "Total number of lease cancel transactions broadcast to the node.",
+	})
+	cancelsConfirmedTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "cancels_confirmed_total",
+		Help: "Total number of lease cancel transactions confirmed on the blockchain.",
+	})
+	broadcastFailuresTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "broadcast_failures_total",
+		Help: "Total number of lease cancel transactions that failed to broadcast.",
+	})
+	cancelConfirmationSeconds = promauto.NewHistogram(prometheus.HistogramOpts{
+		Name: "cancel_confirmation_seconds",
+		Help: "Time spent waiting for a lease cancel transaction to be confirmed on the blockchain.",
+	})
+	broadcastLatencySeconds = promauto.NewHistogram(prometheus.HistogramOpts{
+		Name: "broadcast_latency_seconds",
+		Help: "Time spent broadcasting a single lease cancel transaction to the node.",
+	})
+	nodeHeight = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "node_height",
+		Help: "Blockchain height last observed on the connected node.",
+	})
+	extraFeeWaves = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "extra_fee_waves",
+		Help: "Extra fee, in WAVES, charged for the most recently signed cancel transaction above the standard fee.",
+	})
+)
+
+// startMetricsServer starts an HTTP server exposing Prometheus metrics via
+// promhttp.Handler() at addr, returning immediately. The caller is
+// responsible for shutting it down.
+func startMetricsServer(addr string) *http.Server {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+	srv := &http.Server{Addr: addr, Handler: mux}
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			logf("error", "Metrics server failed: %v", err)
+		}
+	}()
+	return srv
+}
+
+// stopMetricsServer shuts srv down, if it was started.
+func stopMetricsServer(ctx context.Context, srv *http.Server) {
+	if srv == nil {
+		return
+	}
+	_ = srv.Shutdown(ctx)
+}
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/karalabe/usb"
+	"github.com/wavesplatform/gowaves/pkg/crypto"
+	"github.com/wavesplatform/gowaves/pkg/proto"
+)
+
+// Signer abstracts over where the account's private key material lives, so
+// the rest of the tool can sign lease cancel transactions without knowing
+// whether the key is held in process memory or on an external device.
+type Signer interface {
+	PublicKey() crypto.PublicKey
+	SignTx(scheme proto.Scheme, tx proto.Transaction) error
+}
+
+// newSigner constructs the Signer requested by kind ("memory" or "ledger")
+// and returns it together with its public key.
+func newSigner(kind, accountSK, ledgerPath string) (Signer, crypto.PublicKey, error) {
+	switch kind {
+	case "memory":
+		sk, err := crypto.NewSecretKeyFromBase58(accountSK)
+		if err != nil {
+			return nil, crypto.PublicKey{}, err
+		}
+		s := newMemorySigner(sk)
+		return s, s.PublicKey(), nil
+	case "ledger":
+		s, err := newLedgerSigner(ledgerPath)
+		if err != nil {
+			return nil, crypto.PublicKey{}, err
+		}
+		return s, s.PublicKey(), nil
+	default:
+		return nil, crypto.PublicKey{}, fmt.Errorf("unknown signer '%s'", kind)
+	}
+}
+
+// memorySigner signs transactions with a secret key held in process memory.
+type memorySigner struct {
+	sk crypto.SecretKey
+	pk crypto.PublicKey
+}
+
+func newMemorySigner(sk crypto.SecretKey) *memorySigner {
+	return &memorySigner{sk: sk, pk: crypto.GeneratePublicKey(sk)}
+}
+
+func (s *memorySigner) PublicKey() crypto.PublicKey {
+	return s.pk
+}
+
+func (s *memorySigner) SignTx(scheme proto.Scheme, tx proto.Transaction) error {
+	cancel, ok := tx.(*proto.LeaseCancelWithProofs)
+	if !ok {
+		return fmt.Errorf("unsupported transaction type %T", tx)
+	}
+	return cancel.Sign(scheme, s.sk)
+}
+
+const (
+	ledgerVendorID        = 0x2c97 // Ledger Nano S/X/S Plus USB vendor ID
+	ledgerWavesAppCLA     = 0x80
+	ledgerInsGetPublicKey = 0x04
+	ledgerInsSignTx       = 0x02
+)
+
+// ledgerSigner signs transactions using the Waves app running on a Ledger
+// hardware wallet connected over USB, so the private key never leaves the
+// device. It mirrors the hardware-wallet abstraction used by go-ethereum's
+// accounts/usbwallet package.
+type ledgerSigner struct {
+	mu     sync.Mutex
+	device usb.Device
+	path   []uint32
+	pk     crypto.PublicKey
+}
+
+// newLedgerSigner opens the first connected Ledger device and reads the
+// account's public key for the given BIP-32 derivation path, e.g.
+// "44'/5741564'/0'/0'/0'" (5741564 is Waves' SLIP-44 coin type).
+func newLedgerSigner(path string) (*ledgerSigner, error) {
+	derivationPath, err := parseDerivationPath(path)
+	if err != nil {
+		return nil, fmt.Errorf("invalid derivation path '%s': %w", path, err)
+	}
+	infos, err := usb.Enumerate(ledgerVendorID, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to enumerate USB devices: %w", err)
+	}
+	if len(infos) == 0 {
+		return nil, fmt.Errorf("no Ledger device found")
+	}
+	device, err := infos[0].Open()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open Ledger device: %w", err)
+	}
+	s := &ledgerSigner{device: device, path: derivationPath}
+	pk, err := s.exchangePublicKey()
+	if err != nil {
+		_ = device.Close()
+		return nil, fmt.Errorf("failed to read public key from Ledger: %w", err)
+	}
+	s.pk = pk
+	return s, nil
+}
+
+func (s *ledgerSigner) PublicKey() crypto.PublicKey {
+	return s.pk
+}
+
+func (s *ledgerSigner) SignTx(scheme proto.Scheme, tx proto.Transaction) error {
+	cancel, ok := tx.(*proto.LeaseCancelWithProofs)
+	if !ok {
+		return fmt.Errorf("unsupported transaction type %T", tx)
+	}
+	body, err := proto.MarshalTxBody(scheme, cancel)
+	if err != nil {
+		return err
+	}
+	sig, err := s.exchangeSignature(body)
+	if err != nil {
+		return err
+	}
+	cancel.Proofs = proto.NewProofsFromSignature(&sig)
+	return cancel.GenerateID(scheme)
+}
+
+// exchangePublicKey requests the public key for s.path from the Waves Ledger
+// app via an APDU command.
+func (s *ledgerSigner) exchangePublicKey() (crypto.PublicKey, error) {
+	resp, err := s.exchange(ledgerInsGetPublicKey, encodeDerivationPath(s.path))
+	if err != nil {
+		return crypto.PublicKey{}, err
+	}
+	return crypto.NewPublicKeyFromBytes(resp)
+}
+
+// exchangeSignature sends the serialized transaction body to the Waves
+// Ledger app and returns the resulting signature bytes.
+func (s *ledgerSigner) exchangeSignature(body []byte) (crypto.Signature, error) {
+	resp, err := s.exchange(ledgerInsSignTx, append(encodeDerivationPath(s.path), body...))
+	if err != nil {
+		return crypto.Signature{}, err
+	}
+	return crypto.NewSignatureFromBytes(resp)
+}
+
+// exchange sends a single APDU command to the device and returns its
+// response payload, stripped of the trailing status word. It is guarded by
+// s.mu, since a USB HID device can't serve concurrent request/response
+// pairs and signBatch may call SignTx from several worker goroutines.
+func (s *ledgerSigner) exchange(ins byte, data []byte) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	apdu := append([]byte{ledgerWavesAppCLA, ins, 0x00, 0x00, byte(len(data))}, data...)
+	if _, err := s.device.Write(apdu); err != nil {
+		return nil, err
+	}
+	resp := make([]byte, 256)
+	n, err := s.device.Read(resp)
+	if err != nil {
+		return nil, err
+	}
+	if n < 2 {
+		return nil, fmt.Errorf("short response from Ledger device")
+	}
+	sw := uint16(resp[n-2])<<8 | uint16(resp[n-1])
+	if sw != 0x9000 {
+		return nil, fmt.Errorf("ledger device returned status 0x%04x", sw)
+	}
+	return resp[:n-2], nil
+}
+
+// parseDerivationPath parses a BIP-32 path such as "44'/5741564'/0'/0'/0'"
+// into its component indexes, hardening each one as indicated by a trailing
+// apostrophe.
+func parseDerivationPath(path string) ([]uint32, error) {
+	parts := strings.Split(path, "/")
+	result := make([]uint32, 0, len(parts))
+	for _, p := range parts {
+		hardened := strings.HasSuffix(p, "'")
+		p = strings.TrimSuffix(p, "'")
+		v, err := strconv.ParseUint(p, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid path component '%s': %w", p, err)
+		}
+		if hardened {
+			v += 0x80000000
+		}
+		result = append(result, uint32(v))
+	}
+	return result, nil
+}
+
+// encodeDerivationPath serializes a derivation path the way the Waves Ledger
+// app expects it: a leading count byte followed by big-endian uint32s.
+func encodeDerivationPath(path []uint32) []byte {
+	out := make([]byte, 1, 1+4*len(path))
+	out[0] = byte(len(path))
+	for _, v := range path {
+		out = append(out, byte(v>>24), byte(v>>16), byte(v>>8), byte(v))
+	}
+	return out
+}